@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	fasthttp "github.com/valyala/fasthttp"
+)
+
+// These benchmarks live alongside the code they exercise (package main)
+// rather than under ./bench: package main can't be imported by another
+// directory, and this repo has no go.mod/module path to support splitting
+// the Requester types into an importable package.
+
+func benchServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+}
+
+//BenchmarkNetHTTPRequester measures the net/http backend against a local server
+func BenchmarkNetHTTPRequester(b *testing.B) {
+	srv := benchServer()
+	defer srv.Close()
+
+	r := &NetHTTPRequester{Client: srv.Client(), IncludeLength: true}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Do(ctx, http.MethodGet, srv.URL, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//BenchmarkFastHTTPRequester measures the fasthttp backend against a local server
+func BenchmarkFastHTTPRequester(b *testing.B) {
+	srv := benchServer()
+	defer srv.Close()
+
+	r := &FastHTTPRequester{Client: &fasthttp.Client{}, IncludeLength: true, Timeout: 5 * time.Second}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Do(ctx, http.MethodGet, srv.URL, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
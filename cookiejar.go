@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+)
+
+//FileCookieJar wraps the standard library's cookiejar.Jar -- which already
+//implements RFC 6265 cookie dedup, expiry, and domain/path scoping -- with a
+//JSON file on disk, so a session (e.g. from a prior login) can be reused
+//across runs instead of being dropped when the process exits.
+type FileCookieJar struct {
+	mu    sync.Mutex
+	path  string
+	jar   *cookiejar.Jar
+	hosts map[string]*url.URL
+}
+
+//NewFileCookieJar loads cookies previously saved to path, if any, replaying
+//them into a fresh cookiejar.Jar, and returns a jar that Save will write
+//back to the same file.
+func NewFileCookieJar(path string) (*FileCookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fj := &FileCookieJar{path: path, jar: jar, hosts: map[string]*url.URL{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fj, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return fj, nil
+	}
+
+	var saved map[string][]*http.Cookie
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+
+	for rawURL, cookies := range saved {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		fj.jar.SetCookies(u, cookies)
+		fj.hosts[u.Host] = u
+	}
+
+	return fj, nil
+}
+
+//Cookies implements http.CookieJar by delegating to the wrapped cookiejar.Jar
+func (j *FileCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+//SetCookies implements http.CookieJar, delegating to the wrapped
+//cookiejar.Jar (which handles dedup/expiry/domain-matching per RFC 6265)
+//and remembering u so Save can ask the jar for this host's cookies back.
+func (j *FileCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	j.hosts[u.Host] = u
+	j.mu.Unlock()
+
+	j.jar.SetCookies(u, cookies)
+}
+
+//Save writes the jar's current cookies, for every host seen, back to the
+//file it was loaded from.
+func (j *FileCookieJar) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	saved := map[string][]*http.Cookie{}
+	for _, u := range j.hosts {
+		if cookies := j.jar.Cookies(u); len(cookies) > 0 {
+			saved[u.String()] = cookies
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(j.path, data, 0600)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	color "github.com/fatih/color"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DetectWildcard probes the base URL with a random, almost certainly
+// non-existent path so CheckDir can recognise and suppress servers that
+// answer every path with the same "found" response.
+func DetectWildcard(state *State) {
+	if state.BaseURL == "" {
+		return
+	}
+
+	probe := "/" + uuid.NewV4().String() + "/"
+	result, err := state.Requester.Do(state.Ctx, http.MethodGet, state.BaseURL+probe, state.Headers)
+
+	if err != nil || result.Status < 200 || result.Status >= 400 {
+		return
+	}
+
+	state.WildcardDetected = true
+	state.WildcardStatus = result.Status
+	state.WildcardLength = result.Length
+
+	if state.Verbose {
+		color.HiYellow("[!] Wildcard response detected (status %d, length %d), results matching it will be suppressed\n", state.WildcardStatus, state.WildcardLength)
+	}
+}
+
+// isWildcard reports whether a response matches the previously detected
+// wildcard signature and should therefore be treated as a false positive.
+func isWildcard(state *State, status int, length int64) bool {
+	return state.WildcardDetected && status == state.WildcardStatus && length == state.WildcardLength
+}
+
+// dirURIs builds the list of URIs to try for a single wordlist entry,
+// appending each configured extension (-x) alongside the bare word.
+func dirURIs(word string, state *State) []string {
+	uris := []string{"/" + word}
+
+	for _, ext := range state.Extensions {
+		uris = append(uris, fmt.Sprintf("/%s.%s", word, ext))
+	}
+
+	return uris
+}
+
+//CheckDir concatenates a wordlist entry onto the base URL (-u) and reports
+//any hit that doesn't match the detected wildcard signature
+func CheckDir(word string, state *State) {
+	for _, uri := range dirURIs(word, state) {
+		result, method, err := Probe(state, state.BaseURL+uri)
+
+		if err != nil {
+			continue
+		}
+
+		if isWildcard(state, result.Status, result.Length) {
+			continue
+		}
+
+		r := URLResponse{
+			StatusCode: fmt.Sprintf("%d %s", result.Status, http.StatusText(result.Status)),
+			URL:        state.BaseURL + uri,
+			Method:     method,
+			Length:     result.Length,
+			FinalURL:   result.FinalURL,
+			Redirect:   result.Redirect,
+			ElapsedMS:  result.Elapsed.Milliseconds(),
+		}
+		state.Output.Write(r)
+	}
+}
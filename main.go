@@ -2,9 +2,11 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
@@ -13,22 +15,70 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"unicode/utf8"
 
 	color "github.com/fatih/color"
+	fasthttp "github.com/valyala/fasthttp"
+	rate "golang.org/x/time/rate"
 )
 
 //ProcessorFunc type for delegating operations in state to a function that accepts these parameters
-type ProcessorFunc func(entity string, state *State) []URLResponse
+type ProcessorFunc func(entity string, state *State)
 
 //PrintResultFunc type for delegating print operations in state to a function that accepts these parameters
 type PrintResultFunc func(response *URLResponse)
 
+//HostLimiter hands out a per-host rate.Limiter, lazily creating one the
+//first time a host is seen, so --rps enforces its documented per-host
+//budget instead of being shared by every target in a multi-host wordlist.
+type HostLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	rps      float64
+	burst    int
+}
+
+//NewHostLimiter builds a HostLimiter that caps each distinct host at rps
+//requests per second, with the given burst
+func NewHostLimiter(rps float64, burst int) *HostLimiter {
+	return &HostLimiter{limiters: map[string]*rate.Limiter{}, rps: rps, burst: burst}
+}
+
+//Wait blocks until host's limiter allows another request, or ctx is done
+func (h *HostLimiter) Wait(ctx context.Context, host string) error {
+	h.mu.Lock()
+	limiter, ok := h.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(h.rps), h.burst)
+		h.limiters[host] = limiter
+	}
+	h.mu.Unlock()
+
+	return limiter.Wait(ctx)
+}
+
 //IntSet struct for map of integers
 type IntSet struct {
 	set map[int]bool
 }
 
+//headerFlag implements flag.Value to collect repeatable -H "Key: Value" flags
+type headerFlag struct {
+	headers map[string]string
+}
+
+func (h *headerFlag) String() string {
+	return ""
+}
+
+func (h *headerFlag) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid header %q, expected \"Key: Value\"", value)
+	}
+	h.headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	return nil
+}
+
 //StringSet struct for map of strings
 type StringSet struct {
 	set map[string]bool
@@ -36,8 +86,13 @@ type StringSet struct {
 
 //URLResponse struct for housing an HTTPResponse
 type URLResponse struct {
-	StatusCode string
-	URL        string
+	URL        string `json:"url"`
+	StatusCode string `json:"status"`
+	Method     string `json:"method"`
+	Length     int64  `json:"length"`
+	FinalURL   string `json:"final_url,omitempty"`
+	Redirect   string `json:"redirect,omitempty"`
+	ElapsedMS  int64  `json:"elapsed_ms"`
 }
 
 //URLResponseSet struct for housing a map of Responses
@@ -65,67 +120,6 @@ func (set *URLResponseSet) Contains(r URLResponse) bool {
 	return found
 }
 
-// Small helper to combine URL with URI then make a
-// request to the generated location.
-func GoGet(s *State, url, uri, cookie string) (*int, *int64) {
-	return MakeRequest(s, url+uri, cookie)
-}
-
-// Make a request to the given URL.
-func MakeRequest(s *State, fullUrl, cookie string) (*int, *int64) {
-	req, err := http.NewRequest("GET", fullUrl, nil)
-
-	if err != nil {
-		return nil, nil
-	}
-
-	if cookie != "" {
-		req.Header.Set("Cookie", cookie)
-	}
-
-	// if s.UserAgent != "" {
-	// 	req.Header.Set("User-Agent", s.UserAgent)
-	// }
-
-	// if s.Username != "" {
-	// 	req.SetBasicAuth(s.Username, s.Password)
-	// }
-
-	resp, err := s.Client.Do(req)
-
-	if err != nil {
-		if ue, ok := err.(*url.Error); ok {
-
-			if strings.HasPrefix(ue.Err.Error(), "x509") {
-				fmt.Println("[-] Invalid certificate")
-			}
-
-			if re, ok := ue.Err.(*RedirectError); ok {
-				return &re.StatusCode, nil
-			}
-		}
-		return nil, nil
-	}
-
-	defer resp.Body.Close()
-
-	var length *int64 = nil
-
-	if s.IncludeLength {
-		length = new(int64)
-		if resp.ContentLength <= 0 {
-			body, err := ioutil.ReadAll(resp.Body)
-			if err == nil {
-				*length = int64(utf8.RuneCountInString(string(body)))
-			}
-		} else {
-			*length = resp.ContentLength
-		}
-	}
-
-	return &resp.StatusCode, length
-}
-
 //ContainsAny Check if any of the elements exist
 func (set *URLResponseSet) ContainsAny(rr []URLResponse) bool {
 	for _, r := range rr {
@@ -143,9 +137,8 @@ type State struct {
 	OutputFileName string
 	Wordlist       StringSet
 	StatusCodes    IntSet
-	WriteOutput    bool
-	Responses      []URLResponse
-	ShouldClose    bool
+	Format         string
+	Output         OutputWriter
 	SignalChannel  chan os.Signal
 	// Printer        PrintResultFunc
 	Processor      ProcessorFunc
@@ -153,7 +146,28 @@ type State struct {
 	FollowRedirect bool
 	InsecureSSL    bool
 	IncludeLength  bool
-	Throttle       bool
+	HeadFirst      bool
+
+	Ctx          context.Context
+	Cancel       context.CancelFunc
+	Limiter      *HostLimiter
+	RPS          float64
+	Burst        int
+	Timeout      time.Duration
+	MaxIdleConns int
+
+	Engine    string
+	Requester Requester
+
+	Headers   map[string]string
+	CookieJar *FileCookieJar
+
+	Mode             string
+	BaseURL          string
+	Extensions       []string
+	WildcardDetected bool
+	WildcardStatus   int
+	WildcardLength   int64
 }
 
 //RedirectHandler struct for handling http redirects during runtime
@@ -165,6 +179,7 @@ type RedirectHandler struct {
 //RedirectError struct for status codes in errors
 type RedirectError struct {
 	StatusCode int
+	Location   string
 }
 
 //Add to StringSet
@@ -228,31 +243,6 @@ func (set *IntSet) Contains(i int) bool {
 	return found
 }
 
-//WriteOutput writes program output to a file when configured to do so
-func WriteOutput(state *State) (bool, error) {
-	if state.OutputFileName != "" {
-		outputFile, err := os.Create(state.OutputFileName)
-		if err != nil {
-			color.HiRed("[!] Unable to write to %s, falling back to stdout.\n", state.OutputFileName)
-			return false, err
-		}
-		defer outputFile.Close()
-
-		for u := range state.Responses {
-			write, err := outputFile.WriteString(fmt.Sprintf("%s %s\n", state.Responses[u].URL, state.Responses[u].StatusCode))
-			if err != nil {
-				color.HiRed("Error writing file %s\n", err)
-			}
-			if write > 0 {
-				continue
-			}
-		}
-		outputFile.Sync()
-		return true, nil
-	}
-	return false, nil
-}
-
 //FileExists check if file exists before trying to open it
 func FileExists(wordlist string) bool {
 	if _, err := os.Stat(wordlist); err == nil {
@@ -307,7 +297,7 @@ func (rh *RedirectHandler) RoundTrip(req *http.Request) (resp *http.Response, er
 	switch resp.StatusCode {
 	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
 		http.StatusNotModified, http.StatusUseProxy, http.StatusTemporaryRedirect:
-		return nil, &RedirectError{StatusCode: resp.StatusCode}
+		return nil, &RedirectError{StatusCode: resp.StatusCode, Location: resp.Header.Get("Location")}
 	}
 
 	return resp, err
@@ -326,18 +316,28 @@ func ParseArgs() *State {
 	var codes string
 	var wordlist string
 	var URL string
-	var responses []URLResponse
+	var mode string
+	var extensions string
+	var concurrency int
+	var timeoutSeconds int
+	var engine string
+	var format string
+	var userAgent string
+	var basicAuth string
+	var cookieJarFile string
+	var proxyURL string
 
 	valid := true
 	s := State{
 		StatusCodes: IntSet{set: map[int]bool{}},
 		Wordlist:    StringSet{set: map[string]bool{}},
-		Responses:   responses,
 		Processor:   Check,
 		// Printer:       PrintResponse,
 		IncludeLength: true,
 	}
 
+	headers := headerFlag{headers: map[string]string{}}
+
 	flag.IntVar(&s.Threads, "t", 10, "Number of concurrent threads")
 	flag.BoolVar(&s.Verbose, "v", false, "Verbose output (errors)")
 	flag.StringVar(&s.OutputFileName, "o", "", "Output file to write results to (defaults to stdout)")
@@ -346,9 +346,106 @@ func ParseArgs() *State {
 	flag.StringVar(&codes, "s", "200,204,301,302,307", "Positive status codes")
 	flag.BoolVar(&s.FollowRedirect, "r", false, "Follow redirects")
 	flag.BoolVar(&s.InsecureSSL, "k", false, "Skip SSL certificate verification")
-	flag.BoolVar(&s.Throttle, "-r", false, "Enable throttling or rate limiting")
+	flag.StringVar(&mode, "m", "list", "Scan mode: list (wordlist of full URLs) or dir (wordlist of paths appended to -u)")
+	flag.StringVar(&extensions, "x", "", "Comma separated list of extensions to append to each word in dir mode (e.g. php,html,txt)")
+	flag.IntVar(&concurrency, "concurrency", 0, "Maximum number of in-flight requests (overrides -t)")
+	flag.Float64Var(&s.RPS, "rps", 0, "Maximum requests per second, per host (0 = unlimited)")
+	flag.IntVar(&s.Burst, "burst", 1, "Burst size allowed by the --rps limiter")
+	flag.IntVar(&timeoutSeconds, "timeout", 10, "Per-request timeout, in seconds")
+	flag.IntVar(&s.MaxIdleConns, "max-idle-conns", 100, "Maximum idle connections kept open by the shared HTTP client")
+	flag.StringVar(&engine, "engine", "net-http", "Request backend: net-http or fasthttp")
+	flag.BoolVar(&s.HeadFirst, "head-first", false, "Probe with HEAD first, falling back to GET when unsupported or length is unavailable")
+	flag.StringVar(&format, "format", "text", "Output format: text, json, jsonl, or csv")
+	flag.Var(&headers, "H", "Custom header to send, as \"Key: Value\" (repeatable)")
+	flag.StringVar(&userAgent, "a", "", "User-Agent header to send")
+	flag.StringVar(&basicAuth, "U", "", "Basic auth credentials, as user:pass")
+	flag.StringVar(&cookieJarFile, "cookie-jar", "", "File to load/persist cookies to across runs")
+	flag.StringVar(&proxyURL, "proxy", "", "Proxy URL to route requests through, e.g. http://host:port or socks5://host:port")
 	flag.Parse()
 
+	if concurrency > 0 {
+		s.Threads = concurrency
+	}
+
+	s.Timeout = time.Duration(timeoutSeconds) * time.Second
+	s.Ctx, s.Cancel = context.WithCancel(context.Background())
+
+	if s.RPS > 0 {
+		s.Limiter = NewHostLimiter(s.RPS, s.Burst)
+	}
+
+	s.Headers = headers.headers
+	if userAgent != "" {
+		s.Headers["User-Agent"] = userAgent
+	}
+	if basicAuth != "" {
+		user, pass, ok := strings.Cut(basicAuth, ":")
+		if !ok {
+			color.HiRed("[!] Invalid basic auth (-U) %s, must be \"user:pass\"\n", basicAuth)
+			valid = false
+		} else {
+			s.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+		}
+	}
+
+	if cookieJarFile != "" {
+		jar, err := NewFileCookieJar(cookieJarFile)
+		if err != nil {
+			color.HiRed("[!] Unable to load cookie jar (--cookie-jar) %s: %s\n", cookieJarFile, err)
+			valid = false
+		} else {
+			s.CookieJar = jar
+		}
+	}
+
+	transport := &http.Transport{
+		MaxIdleConns:        s.MaxIdleConns,
+		MaxIdleConnsPerHost: s.MaxIdleConns,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: s.InsecureSSL},
+	}
+
+	if proxyURL != "" {
+		if err := configureProxy(transport, proxyURL); err != nil {
+			color.HiRed("[!] Invalid proxy (--proxy) %s: %s\n", proxyURL, err)
+			valid = false
+		}
+	}
+
+	s.Client = &http.Client{
+		Timeout: s.Timeout,
+		Transport: &RedirectHandler{
+			State:     &s,
+			Transport: transport,
+		},
+	}
+	if s.CookieJar != nil {
+		s.Client.Jar = s.CookieJar
+	}
+
+	s.Engine = strings.ToLower(engine)
+	switch s.Engine {
+	case "fasthttp":
+		if proxyURL != "" || s.CookieJar != nil {
+			color.HiRed("[!] --proxy and --cookie-jar are only wired through the net-http transport, not --engine fasthttp\n")
+		}
+		s.Requester = &FastHTTPRequester{
+			Client: &fasthttp.Client{
+				ReadTimeout:     s.Timeout,
+				WriteTimeout:    s.Timeout,
+				MaxConnsPerHost: s.MaxIdleConns,
+				TLSConfig:       &tls.Config{InsecureSkipVerify: s.InsecureSSL},
+			},
+			IncludeLength:  s.IncludeLength,
+			Timeout:        s.Timeout,
+			FollowRedirect: s.FollowRedirect,
+		}
+	case "net-http":
+		s.Requester = &NetHTTPRequester{Client: s.Client, IncludeLength: s.IncludeLength}
+	default:
+		color.HiRed("[!] Invalid engine (--engine) %s, must be \"net-http\" or \"fasthttp\"\n", engine)
+		valid = false
+	}
+
 	if s.Threads < 0 {
 		color.HiRed("[!] Invalid number of threads (-t) %d\n", s.Threads)
 		valid = false
@@ -359,8 +456,39 @@ func ParseArgs() *State {
 		valid = false
 	}
 
-	if *&s.OutputFileName != "" {
-		s.WriteOutput = true
+	s.Format = strings.ToLower(format)
+	output, err := NewOutputWriter(&s)
+	if err != nil {
+		color.HiRed("[!] Invalid output configuration: %s\n", err)
+		valid = false
+	}
+	s.Output = output
+
+	s.Mode = strings.ToLower(mode)
+	if s.Mode != "list" && s.Mode != "dir" {
+		color.HiRed("[!] Invalid mode (-m) %s, must be \"list\" or \"dir\"\n", mode)
+		valid = false
+	}
+
+	if extensions != "" {
+		for _, ext := range strings.Split(extensions, ",") {
+			if ext = strings.TrimSpace(strings.TrimPrefix(ext, ".")); ext != "" {
+				s.Extensions = append(s.Extensions, ext)
+			}
+		}
+	}
+
+	if s.Mode == "dir" {
+		if URL == "" {
+			color.HiRed("[!] Dir mode requires a base URL (-u)\n")
+			valid = false
+		}
+		if wordlist == "" {
+			color.HiRed("[!] Dir mode requires a wordlist of paths (-w)\n")
+			valid = false
+		}
+		s.BaseURL = strings.TrimSuffix(PrefixURL(URL), "/")
+		s.Processor = CheckDir
 	}
 
 	if wordlist != "" {
@@ -373,6 +501,10 @@ func ParseArgs() *State {
 		s.Wordlist.Add(URL)
 	}
 
+	if valid && s.Mode == "dir" {
+		DetectWildcard(&s)
+	}
+
 	if valid {
 		PrintBanner(&s)
 		return &s
@@ -423,6 +555,22 @@ func PrintOptions(state *State) {
 		if len(state.StatusCodes.set) > 0 {
 			color.HiCyan("-- [+] StatusCodes: %s\n", state.StatusCodes.JoinSet())
 		}
+
+		color.HiCyan("[+] Mode: %s\n", state.Mode)
+		if state.Mode == "dir" {
+			color.HiCyan("[+] Base URL: %s\n", state.BaseURL)
+		}
+
+		if state.RPS > 0 {
+			color.HiCyan("[+] Rate limit: %.2f req/s (burst %d)\n", state.RPS, state.Burst)
+		}
+
+		color.HiCyan("[+] Engine: %s\n", state.Engine)
+		if state.HeadFirst {
+			color.HiCyan("[+] HEAD-first probing enabled\n")
+		}
+
+		color.HiCyan("[+] Output format: %s\n", state.Format)
 	}
 }
 
@@ -433,18 +581,19 @@ func PrintResponse(response *URLResponse) {
 	status, err := strconv.Atoi(statusCode[:strings.Index(statusCode, " ")])
 
 	if err == nil {
+		suffix := fmt.Sprintf("[%s, %d bytes]", response.Method, response.Length)
 		switch {
 		case status > 499:
-			color.HiRed("[!] %s %s\n", url, statusCode)
+			color.HiRed("[!] %s %s %s\n", url, statusCode, suffix)
 			break
 		case status > 399:
-			color.Magenta("[+] %s %s\n", url, statusCode)
+			color.Magenta("[+] %s %s %s\n", url, statusCode, suffix)
 			break
 		case status > 299:
-			color.Yellow("[+] %s %s\n", url, statusCode)
+			color.Yellow("[+] %s %s %s\n", url, statusCode, suffix)
 			break
 		default:
-			color.Green("[+] %s %s\n", url, statusCode)
+			color.Green("[+] %s %s %s\n", url, statusCode, suffix)
 			break
 		}
 	}
@@ -458,38 +607,24 @@ func PrefixURL(url string) string {
 	return url
 }
 
-//Request request using http library
-func Request(url string) *http.Response {
-	resp, err := http.Get(url)
-
-	if err != nil {
-		return nil
-	}
-
-	if err == nil {
-		defer resp.Body.Close()
-	}
-
-	return resp
-}
-
 //Check does a GET for a URL
-func Check(url string, state *State) []URLResponse {
+func Check(url string, state *State) {
 	url = PrefixURL(url)
-	resp := Request(url)
-	var r URLResponse
+	result, method, err := Probe(state, url)
 
-	if resp != nil {
-		r = URLResponse{
-			StatusCode: resp.Status,
-			URL:        url,
-		}
-		PrintResponse(&r)
-		return append(state.Responses, r)
+	if err != nil {
+		return
 	}
-	return state.Responses
 
-	// responseChannel <- r
+	state.Output.Write(URLResponse{
+		URL:        url,
+		StatusCode: fmt.Sprintf("%d %s", result.Status, http.StatusText(result.Status)),
+		Method:     method,
+		Length:     result.Length,
+		FinalURL:   result.FinalURL,
+		Redirect:   result.Redirect,
+		ElapsedMS:  result.Elapsed.Milliseconds(),
+	})
 }
 
 //StartSignalHandler creates a handler to watch for CTRL+C
@@ -497,85 +632,87 @@ func StartSignalHandler(state *State) {
 	state.SignalChannel = make(chan os.Signal, 1)
 	signal.Notify(state.SignalChannel, os.Interrupt)
 	go func() {
-		for _ = range state.SignalChannel {
+		for range state.SignalChannel {
 			// caught CTRL+C
 			if state.Verbose {
 				color.HiCyan("[!] Keyboard interrupt detected, terminating.")
-				state.ShouldClose = true
 			}
+			state.Cancel()
 		}
 	}()
 }
 
-//Process runtime config and execute
+//requestHost reports the host a wordlist entry will actually be requested
+//against, so the per-host rate limiter keys on the right target: the base
+//URL in dir mode, or the entry itself (prefixed) in list mode.
+func requestHost(word string, state *State) string {
+	target := word
+	if state.Mode == "dir" {
+		target = state.BaseURL
+	} else {
+		target = PrefixURL(word)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	return u.Host
+}
+
+//Process runtime config and execute using a bounded worker pool
 func Process(state *State) {
 	// channels used for comms
 	urlChannel := make(chan string, state.Threads)
-	// responseChannel := make(chan URLResponse)
 
-	// Use a wait group for waiting for all threads
+	// Use a wait group for waiting for all workers
 	// to finish
 	processorGroup := new(sync.WaitGroup)
 	processorGroup.Add(state.Threads)
-	// printerGroup := new(sync.WaitGroup)
-	// printerGroup.Add(1)
 
 	for i := 0; i < state.Threads; i++ {
 		go func() {
-			for {
-				url := <-urlChannel
+			defer processorGroup.Done()
 
-				// Did we reach the end? If so break.
-				if url == "" {
-					break
+			for word := range urlChannel {
+				if state.Limiter != nil {
+					if err := state.Limiter.Wait(state.Ctx, requestHost(word, state)); err != nil {
+						return
+					}
 				}
 
 				// Mode-specific processing
-				state.Responses = state.Processor(url, state)
+				state.Processor(word, state)
 			}
-
-			// Indicate to the wait group that the thread
-			// has finished.
-			processorGroup.Done()
 		}()
 	}
 
-	// Single goroutine which handles the results as they
-	// appear from the worker threads.
-	// go func() {
-	// 	for r := range responseChannel {
-	// 		state.Printer(&r)
-	// 	}
-	// 	printerGroup.Done()
-	// }()
 	var i int
-	sleepTime := time.Duration(5) * time.Second
-
+feed:
 	for word := range state.Wordlist.set {
-		if i > 100 && i%100 == 0 {
-			if state.Verbose {
-				color.HiGreen("%d out of %d URLs checked.", i, len(state.Wordlist.set))
-			}
-			if state.Throttle {
-				color.HiGreen("Pausing for %d ... seconds\n", sleepTime/time.Second)
-				time.Sleep(sleepTime)
-			}
+		if state.Verbose && i > 0 && i%100 == 0 {
+			color.HiGreen("%d out of %d URLs checked.", i, len(state.Wordlist.set))
 		}
 
-		if state.ShouldClose {
-			break
+		select {
+		case <-state.Ctx.Done():
+			break feed
+		case urlChannel <- word:
+			i++
 		}
-		urlChannel <- word
-		i++
 	}
 
 	close(urlChannel)
 	processorGroup.Wait()
-	// close(responseChannel)
-	// printerGroup.Wait()
 
-	if state.WriteOutput {
-		WriteOutput(state)
+	if err := state.Output.Close(); err != nil {
+		color.HiRed("[!] Error finalizing output: %s\n", err)
+	}
+
+	if state.CookieJar != nil {
+		if err := state.CookieJar.Save(); err != nil {
+			color.HiRed("[!] Error saving cookie jar: %s\n", err)
+		}
 	}
 }
 
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//OutputWriter streams a URLResponse as it's produced by a worker, so results
+//from many goroutines can be written safely without a shared results slice.
+type OutputWriter interface {
+	Write(r URLResponse)
+	Close() error
+}
+
+//statusClass buckets a "200 OK"-style status string into a 1xx-5xx class,
+//falling back to "???" when it can't be parsed.
+func statusClass(statusCode string) string {
+	if i := strings.Index(statusCode, " "); i > 0 {
+		statusCode = statusCode[:i]
+	}
+	if len(statusCode) > 0 {
+		return string(statusCode[0]) + "xx"
+	}
+	return "???"
+}
+
+//NewOutputWriter builds the OutputWriter selected by state.Format (-o/--format),
+//opening state.OutputFileName when set or defaulting to stdout.
+func NewOutputWriter(state *State) (OutputWriter, error) {
+	file := os.Stdout
+	if state.OutputFileName != "" {
+		f, err := os.Create(state.OutputFileName)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+
+	switch state.Format {
+	case "text":
+		return &TextOutputWriter{file: file}, nil
+	case "json":
+		return &JSONOutputWriter{file: file}, nil
+	case "jsonl":
+		return &JSONLOutputWriter{file: file}, nil
+	case "csv":
+		return &CSVOutputWriter{file: file, writer: csv.NewWriter(file)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, must be \"text\", \"json\", \"jsonl\", or \"csv\"", state.Format)
+	}
+}
+
+//TextOutputWriter prints each result through PrintResponse as it arrives,
+//matching the tool's original colorized console output.
+type TextOutputWriter struct {
+	mu      sync.Mutex
+	file    *os.File
+	summary map[string]int
+}
+
+//Write prints a single result
+func (w *TextOutputWriter) Write(r URLResponse) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.summary == nil {
+		w.summary = map[string]int{}
+	}
+	w.summary[statusClass(r.StatusCode)]++
+
+	PrintResponse(&r)
+}
+
+//Close prints a summary of how many results fell into each status class
+func (w *TextOutputWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.summary) > 0 {
+		fmt.Println("")
+		for _, class := range []string{"2xx", "3xx", "4xx", "5xx"} {
+			if n, ok := w.summary[class]; ok {
+				fmt.Printf("[+] %s: %d\n", class, n)
+			}
+		}
+	}
+	return nil
+}
+
+//summaryLine is the trailing JSON record/object reporting how many results
+//fell into each status class, so a consumer piping --format json/jsonl into
+//jq or a SIEM gets totals without having to re-tally the individual results.
+type summaryLine struct {
+	Summary map[string]int `json:"summary"`
+}
+
+//JSONLOutputWriter streams one JSON object per line as results arrive, so
+//large scans don't have to be buffered in memory before they can be read.
+//Close appends one further line: a summaryLine totaling results per status
+//class.
+type JSONLOutputWriter struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	summary map[string]int
+}
+
+//Write encodes and appends a single result line
+func (w *JSONLOutputWriter) Write(r URLResponse) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.encoder == nil {
+		w.encoder = json.NewEncoder(w.file)
+	}
+	if w.summary == nil {
+		w.summary = map[string]int{}
+	}
+	w.summary[statusClass(r.StatusCode)]++
+
+	w.encoder.Encode(r)
+}
+
+//Close appends a summary line and flushes the underlying file, if one was opened
+func (w *JSONLOutputWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.encoder == nil {
+		w.encoder = json.NewEncoder(w.file)
+	}
+	if err := w.encoder.Encode(summaryLine{Summary: w.summary}); err != nil {
+		return err
+	}
+
+	if w.file != os.Stdout {
+		return w.file.Close()
+	}
+	return nil
+}
+
+//jsonReport is the single JSON object written by JSONOutputWriter, pairing
+//the buffered results with a summary of how many fell into each status class.
+type jsonReport struct {
+	Results []URLResponse  `json:"results"`
+	Summary map[string]int `json:"summary"`
+}
+
+//JSONOutputWriter buffers every result and writes a single JSON object on
+//Close, since a valid JSON array/object can't be streamed incrementally.
+type JSONOutputWriter struct {
+	mu        sync.Mutex
+	file      *os.File
+	responses []URLResponse
+}
+
+//Write buffers a single result
+func (w *JSONOutputWriter) Write(r URLResponse) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.responses = append(w.responses, r)
+}
+
+//Close marshals the buffered results and a per-status-class summary as a
+//single JSON object and writes it out
+func (w *JSONOutputWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	summary := map[string]int{}
+	for _, r := range w.responses {
+		summary[statusClass(r.StatusCode)]++
+	}
+
+	encoder := json.NewEncoder(w.file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(jsonReport{Results: w.responses, Summary: summary}); err != nil {
+		return err
+	}
+
+	if w.file != os.Stdout {
+		return w.file.Close()
+	}
+	return nil
+}
+
+//CSVOutputWriter streams one CSV row per result as they arrive.
+type CSVOutputWriter struct {
+	mu          sync.Mutex
+	file        *os.File
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+//Write appends a single result row, writing the header row first if needed
+func (w *CSVOutputWriter) Write(r URLResponse) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.wroteHeader {
+		w.writer.Write([]string{"url", "status", "method", "length", "final_url", "redirect", "elapsed_ms"})
+		w.wroteHeader = true
+	}
+
+	w.writer.Write([]string{
+		r.URL,
+		r.StatusCode,
+		r.Method,
+		strconv.FormatInt(r.Length, 10),
+		r.FinalURL,
+		r.Redirect,
+		strconv.FormatInt(r.ElapsedMS, 10),
+	})
+}
+
+//Close flushes the CSV writer and the underlying file, if one was opened
+func (w *CSVOutputWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return err
+	}
+
+	if w.file != os.Stdout {
+		return w.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	fasthttp "github.com/valyala/fasthttp"
+)
+
+//RequestResult captures everything callers need to build a URLResponse from
+//a single request, regardless of which backend performed it.
+type RequestResult struct {
+	Status    int
+	Length    int64
+	HasLength bool
+	FinalURL  string
+	Redirect  string
+	Elapsed   time.Duration
+}
+
+//Requester abstracts the HTTP backend used to perform a single request, so
+//Check and CheckDir don't need to know whether they're talking to net/http
+//or fasthttp.
+type Requester interface {
+	Do(ctx context.Context, method, requestURL string, headers map[string]string) (RequestResult, error)
+}
+
+//Probe requests requestURL, trying HEAD first when state.HeadFirst is set and
+//transparently falling back to GET when the server doesn't support HEAD
+//(405/501) or doesn't return a usable Content-Length. It reports which
+//method actually produced the returned result.
+func Probe(state *State, requestURL string) (RequestResult, string, error) {
+	if state.HeadFirst {
+		result, err := state.Requester.Do(state.Ctx, http.MethodHead, requestURL, state.Headers)
+		if err == nil && result.Status != http.StatusMethodNotAllowed && result.Status != http.StatusNotImplemented &&
+			(!state.IncludeLength || result.HasLength) {
+			return result, http.MethodHead, nil
+		}
+	}
+
+	result, err := state.Requester.Do(state.Ctx, http.MethodGet, requestURL, state.Headers)
+	return result, http.MethodGet, err
+}
+
+//NetHTTPRequester implements Requester on top of the shared *http.Client.
+type NetHTTPRequester struct {
+	Client        *http.Client
+	IncludeLength bool
+}
+
+//Do performs the request and reports the status code, body length and
+//redirect/timing details
+func (r *NetHTTPRequester) Do(ctx context.Context, method, requestURL string, headers map[string]string) (RequestResult, error) {
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return RequestResult{}, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		if ue, ok := err.(*url.Error); ok {
+			if strings.HasPrefix(ue.Err.Error(), "x509") {
+				fmt.Println("[-] Invalid certificate")
+			}
+
+			if re, ok := ue.Err.(*RedirectError); ok {
+				return RequestResult{Status: re.StatusCode, Redirect: re.Location, Elapsed: time.Since(start)}, nil
+			}
+		}
+		return RequestResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var length int64
+	var hasLength bool
+	if r.IncludeLength {
+		if resp.ContentLength >= 0 {
+			length = resp.ContentLength
+			hasLength = true
+		} else if method != http.MethodHead {
+			// HEAD responses have no body to read, so a missing
+			// Content-Length header there can't be resolved by reading;
+			// only fall back to reading the body on methods that have one.
+			body, err := ioutil.ReadAll(resp.Body)
+			if err == nil {
+				length = int64(utf8.RuneCountInString(string(body)))
+				hasLength = true
+			}
+		}
+	}
+
+	return RequestResult{
+		Status:    resp.StatusCode,
+		Length:    length,
+		HasLength: hasLength,
+		FinalURL:  resp.Request.URL.String(),
+		Elapsed:   time.Since(start),
+	}, nil
+}
+
+//maxFastHTTPRedirects caps how many hops FastHTTPRequester.Do will follow
+//when FollowRedirect is set, matching fasthttp's own (unexported) default.
+const maxFastHTTPRedirects = 16
+
+//FastHTTPRequester implements Requester using valyala/fasthttp, reusing
+//Acquire/Release pooling to avoid the per-request allocations net/http
+//incurs on large wordlist scans.
+type FastHTTPRequester struct {
+	Client         *fasthttp.Client
+	IncludeLength  bool
+	Timeout        time.Duration
+	FollowRedirect bool
+}
+
+//Do performs the request and reports the status code, body length and
+//redirect/timing details. state.Ctx is created with context.WithCancel and
+//never carries a deadline, so DoDeadline/DoRedirects alone wouldn't notice
+//Ctrl-C until ReadTimeout/WriteTimeout elapsed; racing it against ctx.Done()
+//makes cancellation abort the in-flight request immediately, like
+//NetHTTPRequester. When FollowRedirect is set, DoRedirects is used instead
+//of DoDeadline so --engine fasthttp -r actually follows redirects, matching
+//the net-http backend.
+func (r *FastHTTPRequester) Do(ctx context.Context, method, requestURL string, headers map[string]string) (RequestResult, error) {
+	start := time.Now()
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+
+	req.SetRequestURI(requestURL)
+	req.Header.SetMethod(method)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(r.Timeout)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		if r.FollowRedirect {
+			done <- r.Client.DoRedirects(req, resp, maxFastHTTPRedirects)
+			return
+		}
+		done <- r.Client.DoDeadline(req, resp, deadline)
+	}()
+
+	select {
+	case <-ctx.Done():
+		// req/resp are still owned by the in-flight call above, so they're
+		// deliberately not released here to avoid a reuse-while-in-use race;
+		// they're dropped rather than returned to the pool.
+		return RequestResult{}, ctx.Err()
+	case err := <-done:
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(resp)
+
+		if err != nil {
+			return RequestResult{}, err
+		}
+
+		var length int64
+		var hasLength bool
+		if r.IncludeLength {
+			if cl := resp.Header.ContentLength(); cl >= 0 {
+				length = int64(cl)
+				hasLength = true
+			} else if method != http.MethodHead {
+				// HEAD responses have no body to read, so an unknown/chunked
+				// Content-Length there can't be resolved by reading; only
+				// fall back to the actual body on methods that have one.
+				length = int64(len(resp.Body()))
+				hasLength = true
+			}
+		}
+
+		return RequestResult{
+			Status:    resp.StatusCode(),
+			Length:    length,
+			HasLength: hasLength,
+			FinalURL:  string(req.URI().String()),
+			Redirect:  string(resp.Header.Peek("Location")),
+			Elapsed:   time.Since(start),
+		}, nil
+	}
+}